@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// histSigDigits controls the resolution of the log-linear histogram buckets:
+// each power-of-ten decade is divided into 10^histSigDigits buckets, giving
+// roughly that many significant decimal digits of relative precision on any
+// reported percentile.
+const histSigDigits = 3
+
+// histDecades bounds the histogram's range to 1us-1000s, comfortably wider
+// than any latency this tool will plausibly see.
+const histDecades = 9
+
+// Histogram is a bounded-memory, log-linear latency histogram in the style
+// of HDR histogram: instead of keeping every sample (which is unbounded
+// memory for long -d runs or large -n), it buckets samples into a fixed
+// array sized by significant digits, while tracking count/sum/sum-of-squares
+// exactly so mean and standard deviation aren't approximated at all.
+type Histogram struct {
+	scale   float64 // buckets per decade
+	counts  []int64
+	count   int64
+	sumUs   float64
+	sumSqUs float64
+	min     time.Duration
+	max     time.Duration
+}
+
+// NewHistogram returns an empty Histogram.
+func NewHistogram() *Histogram {
+	scale := math.Pow(10, histSigDigits)
+	return &Histogram{
+		scale:  scale,
+		counts: make([]int64, histDecades*int(scale)+1),
+	}
+}
+
+// Record adds a single sample to the histogram.
+func (h *Histogram) Record(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+
+	us := float64(d.Microseconds())
+	h.sumUs += us
+	h.sumSqUs += us * us
+
+	h.counts[h.bucketIndex(d)]++
+}
+
+// bucketIndex maps a duration to its bucket, clamping to the histogram's
+// configured range.
+func (h *Histogram) bucketIndex(d time.Duration) int {
+	us := d.Microseconds()
+	if us < 1 {
+		us = 1
+	}
+	idx := int(math.Log10(float64(us)) * h.scale)
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(h.counts) {
+		idx = len(h.counts) - 1
+	}
+	return idx
+}
+
+// bucketUpperBoundUs returns the upper edge (in microseconds) of bucket i.
+func (h *Histogram) bucketUpperBoundUs(i int) float64 {
+	return math.Pow(10, float64(i+1)/h.scale)
+}
+
+// Count returns the number of samples recorded.
+func (h *Histogram) Count() int64 { return h.count }
+
+// Min returns the smallest recorded sample, or 0 if none were recorded.
+func (h *Histogram) Min() time.Duration { return h.min }
+
+// Max returns the largest recorded sample, or 0 if none were recorded.
+func (h *Histogram) Max() time.Duration { return h.max }
+
+// MeanMs returns the mean of all recorded samples, in milliseconds.
+func (h *Histogram) MeanMs() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sumUs / float64(h.count) / 1000
+}
+
+// StdDevMs returns the standard deviation of all recorded samples, in
+// milliseconds. It's computed exactly from the running sum and
+// sum-of-squares, so it isn't subject to the histogram's bucket resolution.
+func (h *Histogram) StdDevMs() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	mean := h.sumUs / float64(h.count)
+	variance := h.sumSqUs/float64(h.count) - mean*mean
+	if variance < 0 {
+		// Guard against floating-point noise driving this slightly negative.
+		variance = 0
+	}
+	return math.Sqrt(variance) / 1000
+}
+
+// PercentileMs returns the p-th percentile (0-100) in milliseconds, read off
+// the histogram buckets. The result is accurate to the bucket's upper edge,
+// i.e. within the resolution implied by histSigDigits.
+func (h *Histogram) PercentileMs(p float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(p / 100 * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	var cum int64
+	for i, c := range h.counts {
+		cum += c
+		if cum >= target {
+			return h.bucketUpperBoundUs(i) / 1000
+		}
+	}
+	return float64(h.max.Microseconds()) / 1000
+}
+
+// DumpBuckets writes one line per non-empty bucket as "label\tlowMs\thighMs\tcount",
+// so external tools can render the latency distribution offline.
+func (h *Histogram) DumpBuckets(w io.Writer, label string) {
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		lowMs := math.Pow(10, float64(i)/h.scale) / 1000
+		highMs := h.bucketUpperBoundUs(i) / 1000
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%d\n", label, lowMs, highMs, c)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// LatencyStats is the JSON-friendly view of a Histogram's summary stats.
+type LatencyStats struct {
+	MinMs    float64 `json:"min_ms"`
+	MaxMs    float64 `json:"max_ms"`
+	MeanMs   float64 `json:"mean_ms"`
+	StdDevMs float64 `json:"stddev_ms"`
+	P50Ms    float64 `json:"p50_ms"`
+	P90Ms    float64 `json:"p90_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+	P999Ms   float64 `json:"p999_ms"`
+}
+
+func latencyStatsFromHistogram(h *Histogram) LatencyStats {
+	return LatencyStats{
+		MinMs:    float64(h.Min().Microseconds()) / 1000,
+		MaxMs:    float64(h.Max().Microseconds()) / 1000,
+		MeanMs:   h.MeanMs(),
+		StdDevMs: h.StdDevMs(),
+		P50Ms:    h.PercentileMs(50),
+		P90Ms:    h.PercentileMs(90),
+		P95Ms:    h.PercentileMs(95),
+		P99Ms:    h.PercentileMs(99),
+		P999Ms:   h.PercentileMs(99.9),
+	}
+}
+
+// SampleRecord is one completed (or aborted) request, as emitted by --ndjson,
+// CSV output, or the optional JSON sample list.
+type SampleRecord struct {
+	URL        string  `json:"url"`
+	StatusCode int     `json:"status_code,omitempty"`
+	TTFBMs     float64 `json:"ttfb_ms,omitempty"`
+	TTLBMs     float64 `json:"ttlb_ms,omitempty"`
+	DNSMs      float64 `json:"dns_ms,omitempty"`
+	ConnMs     float64 `json:"conn_ms,omitempty"`
+	TLSMs      float64 `json:"tls_ms,omitempty"`
+	Reused     bool    `json:"reused,omitempty"`
+	Canceled   bool    `json:"canceled,omitempty"`
+	Failed     bool    `json:"failed,omitempty"`
+}
+
+// Report is the full test result, as emitted by -o json.
+type Report struct {
+	URLs        []string `json:"urls"`
+	NumRequests int      `json:"num_requests,omitempty"`
+	Concurrency int      `json:"concurrency"`
+	Duration    string   `json:"duration,omitempty"`
+	RPSLimit    float64  `json:"rps_limit,omitempty"`
+
+	SuccessCount   int     `json:"success_count"`
+	FailureCount   int     `json:"failure_count"`
+	CanceledCount  int     `json:"canceled_count"`
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	ReusedConns    int     `json:"reused_conns"`
+	FreshDials     int     `json:"fresh_dials"`
+
+	TTFB LatencyStats `json:"ttfb"`
+	TTLB LatencyStats `json:"ttlb"`
+	DNS  LatencyStats `json:"dns"`
+	Conn LatencyStats `json:"conn"`
+	TLS  LatencyStats `json:"tls"`
+
+	// PerURL breaks success/failure/latency stats down by target URL.
+	// Only populated when the run had more than one distinct target.
+	PerURL []URLReport `json:"per_url,omitempty"`
+
+	Samples []SampleRecord `json:"samples,omitempty"`
+}
+
+// URLReport is one target URL's slice of a multi-URL report.
+type URLReport struct {
+	URL            string       `json:"url"`
+	SuccessCount   int          `json:"success_count"`
+	FailureCount   int          `json:"failure_count"`
+	CanceledCount  int          `json:"canceled_count"`
+	RequestsPerSec float64      `json:"requests_per_sec"`
+	TTFB           LatencyStats `json:"ttfb"`
+	TTLB           LatencyStats `json:"ttlb"`
+}
+
+// urlAgg accumulates per-URL stats while results are being collected; it's
+// the mutable counterpart of the JSON-friendly URLReport.
+type urlAgg struct {
+	successCount  int
+	failureCount  int
+	canceledCount int
+	ttfbHist      *Histogram
+	ttlbHist      *Histogram
+}
+
+func newURLAgg() *urlAgg {
+	return &urlAgg{ttfbHist: NewHistogram(), ttlbHist: NewHistogram()}
+}
+
+// buildURLReports converts the accumulated per-URL aggregates into the
+// reported form, preserving distinctURLs' order (first-seen in the target
+// list) rather than map iteration order.
+func buildURLReports(distinctURLs []string, perURL map[string]*urlAgg, testDuration time.Duration) []URLReport {
+	reports := make([]URLReport, 0, len(distinctURLs))
+	for _, url := range distinctURLs {
+		agg := perURL[url]
+		reports = append(reports, URLReport{
+			URL:            url,
+			SuccessCount:   agg.successCount,
+			FailureCount:   agg.failureCount,
+			CanceledCount:  agg.canceledCount,
+			RequestsPerSec: float64(agg.successCount+agg.failureCount) / testDuration.Seconds(),
+			TTFB:           latencyStatsFromHistogram(agg.ttfbHist),
+			TTLB:           latencyStatsFromHistogram(agg.ttlbHist),
+		})
+	}
+	return reports
+}
+
+// writeJSONReport writes the full report as a single JSON object.
+func writeJSONReport(w io.Writer, report Report) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintf(w, "error encoding JSON report: %v\n", err)
+	}
+}
+
+// writeCSVReport writes one row per completed (or aborted) request.
+func writeCSVReport(w io.Writer, samples []SampleRecord) {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	cw.Write([]string{"url", "status_code", "ttfb_ms", "ttlb_ms", "dns_ms", "conn_ms", "tls_ms", "reused", "canceled", "failed"})
+	for _, s := range samples {
+		cw.Write([]string{
+			s.URL,
+			strconv.Itoa(s.StatusCode),
+			strconv.FormatFloat(s.TTFBMs, 'f', 3, 64),
+			strconv.FormatFloat(s.TTLBMs, 'f', 3, 64),
+			strconv.FormatFloat(s.DNSMs, 'f', 3, 64),
+			strconv.FormatFloat(s.ConnMs, 'f', 3, 64),
+			strconv.FormatFloat(s.TLSMs, 'f', 3, 64),
+			strconv.FormatBool(s.Reused),
+			strconv.FormatBool(s.Canceled),
+			strconv.FormatBool(s.Failed),
+		})
+	}
+}
+
+// writeTextReport prints the human-readable summary. The count lines keep
+// the original report's labels and column alignment; the latency lines
+// report percentiles and standard deviation rather than min/max/mean.
+func writeTextReport(w io.Writer, report Report, histDump bool, ttfbHist, ttlbHist *Histogram) {
+	fmt.Fprintln(w, "\nResults:")
+	fmt.Fprintf(w, " Total Requests (2XX)..........................: %d\n", report.SuccessCount)
+	fmt.Fprintf(w, " Failed Requests (non-2XX or network error)....: %d\n", report.FailureCount)
+	fmt.Fprintf(w, " Canceled Requests (shutdown)..................: %d\n", report.CanceledCount)
+	fmt.Fprintf(w, " Total Requests Per Second.....................: %.2f\n", report.RequestsPerSec)
+	writeLatencyLine(w, " Time to First Byte", report.TTFB)
+	writeLatencyLine(w, " Time to Last Byte ", report.TTLB)
+
+	if histDump {
+		fmt.Fprintln(w, "\nHistogram Buckets (label\\tlowMs\\thighMs\\tcount):")
+		ttfbHist.DumpBuckets(w, "ttfb")
+		ttlbHist.DumpBuckets(w, "ttlb")
+	}
+
+	fmt.Fprintln(w, "\nConnection Phases:")
+	fmt.Fprintf(w, " DNS Lookup (Min, Max, Mean)...................: %.2f, %.2f, %.2f ms\n", report.DNS.MinMs, report.DNS.MaxMs, report.DNS.MeanMs)
+	fmt.Fprintf(w, " TCP Connect (Min, Max, Mean)..................: %.2f, %.2f, %.2f ms\n", report.Conn.MinMs, report.Conn.MaxMs, report.Conn.MeanMs)
+	fmt.Fprintf(w, " TLS Handshake (Min, Max, Mean)................: %.2f, %.2f, %.2f ms\n", report.TLS.MinMs, report.TLS.MaxMs, report.TLS.MeanMs)
+	fmt.Fprintf(w, " Connections Reused / Fresh Dials..............: %d / %d\n", report.ReusedConns, report.FreshDials)
+
+	if len(report.PerURL) > 0 {
+		fmt.Fprintln(w, "\nPer-URL Breakdown:")
+		for _, u := range report.PerURL {
+			fmt.Fprintf(w, " %s\n", u.URL)
+			fmt.Fprintf(w, "   Success / Failure / Canceled.........: %d / %d / %d\n", u.SuccessCount, u.FailureCount, u.CanceledCount)
+			fmt.Fprintf(w, "   Requests Per Second...................: %.2f\n", u.RequestsPerSec)
+			writeLatencyLine(w, "   Time to First Byte", u.TTFB)
+			writeLatencyLine(w, "   Time to Last Byte ", u.TTLB)
+		}
+	}
+}
+
+// writeLatencyLine prints the percentile and standard-deviation breakdown
+// for a single latency stat (TTFB or TTLB).
+func writeLatencyLine(w io.Writer, label string, s LatencyStats) {
+	fmt.Fprintf(w, "%s (p50, p90, p95, p99, p99.9, stddev): %.2f, %.2f, %.2f, %.2f, %.2f, %.2f ms\n",
+		label, s.P50Ms, s.P90Ms, s.P95Ms, s.P99Ms, s.P999Ms, s.StdDevMs)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// isTTY reports whether f is attached to an interactive terminal, so the
+// live progress bar can be suppressed automatically when output is piped,
+// redirected, or captured (e.g. in CI).
+func isTTY(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressBar renders a single-line status line to w, refreshed in place
+// with a carriage return so repeated calls don't scroll the terminal. It's
+// meant for stderr, so it never mixes with the report itself (which goes to
+// stdout, or -out). It shows completed/total in fixed-count mode, or
+// elapsed/remaining when running for a fixed duration.
+type progressBar struct {
+	w        io.Writer
+	total    int
+	duration time.Duration // 0 means fixed-count mode
+	start    time.Time
+}
+
+func newProgressBar(w io.Writer, total int, duration time.Duration, start time.Time) *progressBar {
+	return &progressBar{w: w, total: total, duration: duration, start: start}
+}
+
+// render draws the current state of the bar: progress, current RPS, the
+// running p95 TTLB, and the error count so far.
+func (p *progressBar) render(completed int, rps, p95Ms float64, errCount int) {
+	var progress string
+	if p.duration > 0 {
+		elapsed := time.Since(p.start)
+		remaining := p.duration - elapsed
+		if remaining < 0 {
+			remaining = 0
+		}
+		progress = fmt.Sprintf("%s elapsed, %s remaining", elapsed.Round(time.Second), remaining.Round(time.Second))
+	} else {
+		progress = fmt.Sprintf("%d/%d", completed, p.total)
+	}
+	fmt.Fprintf(p.w, "\r\033[K %s | %.1f req/s | p95 %.1f ms | errors %d", progress, rps, p95Ms, errCount)
+}
+
+// clear erases the progress line so whatever prints next starts on a clean
+// line instead of overwriting the bar's leftovers.
+func (p *progressBar) clear() {
+	fmt.Fprint(p.w, "\r\033[K")
+}
+
+// printTickSnapshot writes one compact summary line for --tick. Unlike the
+// live bar, it's meant to sit in a log or redirected output, so it's a
+// plain appended line rather than an in-place refresh.
+func printTickSnapshot(w io.Writer, elapsed time.Duration, completed, errCount int, rps, p95Ms float64) {
+	fmt.Fprintf(w, "[%s] %d done, %.1f req/s, %d errors, p95 %.1f ms\n", elapsed.Round(time.Second), completed, rps, errCount, p95Ms)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// urlSpec is one request target: what to request, how, and (for -f files
+// with multiple lines) how heavily to weight it relative to the others.
+type urlSpec struct {
+	method string
+	url    string
+	body   string
+	weight int
+}
+
+// targetsNeedFullRequest reports whether any target needs more than a plain
+// GET with no body, which is all runSequential knows how to issue.
+func targetsNeedFullRequest(targets []urlSpec) bool {
+	for _, t := range targets {
+		if t.method != http.MethodGet || t.body != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getTargets figures out the list of request targets based on flags,
+// in order of precedence: -f, then -u, then a bare arg.
+func getTargets(fileFlag, urlFlag string, args []string) ([]urlSpec, error) {
+	if fileFlag != "" {
+		return readTargets(fileFlag)
+	}
+	if urlFlag != "" {
+		return []urlSpec{{method: http.MethodGet, url: urlFlag, weight: 1}}, nil
+	}
+	if len(args) > 0 {
+		return []urlSpec{{method: http.MethodGet, url: args[0], weight: 1}}, nil
+	}
+	return nil, fmt.Errorf("no URL provided. Use -u, -f, or a command-line argument")
+}
+
+// readTargets (for -f flag) reads a file line by line into a target list.
+// Each non-blank, non-comment line is "[METHOD ]URL[ WEIGHT][ BODY]", where
+// BODY, if present, starts with '{' and runs to the end of the line, e.g.:
+//
+//	https://example.com/a
+//	GET https://example.com/a  3
+//	POST https://example.com/b {"x":1}
+func readTargets(path string) ([]urlSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var targets []urlSpec
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := parseTargetLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		targets = append(targets, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+var httpMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodDelete: true, http.MethodPatch: true, http.MethodHead: true,
+	http.MethodOptions: true,
+}
+
+// parseTargetLine parses one -f line into a urlSpec. A JSON body (starting
+// with '{') is cut off first since it's the one field allowed to contain
+// whitespace; everything before it is then just whitespace-separated
+// [METHOD] URL [WEIGHT].
+func parseTargetLine(line string) (urlSpec, error) {
+	header := line
+	body := ""
+	if idx := strings.IndexByte(line, '{'); idx >= 0 {
+		header = strings.TrimSpace(line[:idx])
+		body = strings.TrimSpace(line[idx:])
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return urlSpec{}, fmt.Errorf("missing URL in line %q", line)
+	}
+
+	method := http.MethodGet
+	i := 0
+	if httpMethods[strings.ToUpper(fields[0])] {
+		method = strings.ToUpper(fields[0])
+		i++
+	}
+	if i >= len(fields) {
+		return urlSpec{}, fmt.Errorf("missing URL in line %q", line)
+	}
+	url := fields[i]
+	i++
+
+	weight := 1
+	if i < len(fields) {
+		w, err := strconv.Atoi(fields[i])
+		if err != nil || w < 1 {
+			return urlSpec{}, fmt.Errorf("invalid weight %q in line %q", fields[i], line)
+		}
+		weight = w
+	}
+
+	return urlSpec{method: method, url: url, body: body, weight: weight}, nil
+}
+
+// targetSampler draws targets with probability proportional to their
+// weight, replacing plain round-robin dispatch so a weighted -f file
+// behaves like a realistic mixed-traffic simulator rather than a
+// single-endpoint hammer.
+type targetSampler struct {
+	targets []urlSpec
+	cumW    []int
+	total   int
+}
+
+func newTargetSampler(targets []urlSpec) *targetSampler {
+	cumW := make([]int, len(targets))
+	total := 0
+	for i, t := range targets {
+		total += t.weight
+		cumW[i] = total
+	}
+	return &targetSampler{targets: targets, cumW: cumW, total: total}
+}
+
+// pick returns a single target, chosen with probability weight/totalWeight.
+func (s *targetSampler) pick() urlSpec {
+	if len(s.targets) == 1 {
+		return s.targets[0]
+	}
+	r := rand.Intn(s.total) + 1
+	idx := sort.SearchInts(s.cumW, r)
+	return s.targets[idx]
+}